@@ -18,15 +18,63 @@ type SharedSecretEncryptionPublicKey [curve25519.PointSize]byte
 
 type encryptedSharedSecret [SharedSecretSize]byte
 
+// Scheme identifies how SharedSecretEncryptions.Encryptions (or its
+// scheme-specific sibling field) was produced, so that a decoder knows which
+// decryption routine to dispatch to.
+type Scheme uint8
+
+const (
+	// SchemeV1 is the legacy single-block AES-128 scheme implemented by
+	// EncryptSharedSecret/DecryptSharedSecret.
+	SchemeV1 Scheme = iota
+
+	// SchemeAEAD is the HKDF-SHA256 + ChaCha20-Poly1305 scheme implemented by
+	// EncryptSharedSecretAEAD/DecryptSharedSecretAEAD.
+	SchemeAEAD
+
+	// SchemeMixed allows a committee of oracles keyed on different curves:
+	// each entry in MixedEncryptions is self-tagged with the KeyType of the
+	// RecipientKey it was encrypted to. Implemented by
+	// EncryptSharedSecretMixed/DecryptSharedSecretMixed*.
+	SchemeMixed
+
+	// SchemeThreshold splits the secret into a Threshold-of-N Shamir sharing
+	// before encrypting each share to its oracle in MixedEncryptions.
+	// Implemented by EncryptSharedSecretThreshold/CombineShares.
+	SchemeThreshold
+)
+
 type SharedSecretEncryptions struct {
+	// Which scheme Encryptions/EncryptionsAEAD were produced with
+	Scheme Scheme
+
 	// (secret key chosen by dealer) * g, X25519 point
 	DiffieHellmanPoint [curve25519.PointSize]byte
 
 	// keccak256 of plaintext sharedSecret
 	SharedSecretHash common.Hash
 
-	// Encryptions of the shared secret with one entry for each oracle
+	// Encryptions of the shared secret with one entry for each oracle.
+	// Only populated when Scheme == SchemeV1.
 	Encryptions []encryptedSharedSecret
+
+	// AEAD-encrypted shares of the shared secret, one entry per oracle.
+	// Only populated when Scheme == SchemeAEAD.
+	EncryptionsAEAD []encryptedSharedSecretAEAD
+
+	// Shares of the shared secret encrypted to a heterogeneous set of
+	// RecipientKeys, one entry per oracle. Populated when
+	// Scheme == SchemeMixed or Scheme == SchemeThreshold (in the latter case,
+	// each entry holds a Shamir share rather than the secret itself).
+	MixedEncryptions []EncryptedShare
+
+	// Number of shares required to reconstruct the secret via CombineShares.
+	// Only populated when Scheme == SchemeThreshold.
+	Threshold uint8
+
+	// Shamir x-coordinate (1..N) of the share in the corresponding
+	// MixedEncryptions entry. Only populated when Scheme == SchemeThreshold.
+	ShareIndex []uint8
 }
 
 // Encrypt one block with AES-128
@@ -90,12 +138,79 @@ func EncryptSharedSecret(
 	}
 
 	return SharedSecretEncryptions{
+		Scheme:             SchemeV1,
 		DiffieHellmanPoint: pkArray,
 		SharedSecretHash:   common.BytesToHash(crypto.Keccak256(sharedSecret[:])),
 		Encryptions:        encryptedSharedSecrets,
 	}
 }
 
+// Decrypt one block with AES-128
+func aesDecryptBlock(key []byte, ciphertext [16]byte) [16]byte {
+	if len(key) != 16 {
+		panic("key has wrong length")
+	}
+
+	cipher, err := aes.NewCipher(key)
+	if err != nil {
+		panic(fmt.Sprintf("Unexpected error during aes.NewCipher: %v", err))
+	}
+
+	var plaintext [16]byte
+	cipher.Decrypt(plaintext[:], ciphertext[:])
+	return plaintext
+}
+
+// DerivePublicKey computes the X25519 public key corresponding to a secret key,
+// for use as an oracle's SharedSecretEncryptionPublicKey.
+func DerivePublicKey(sk [32]byte) SharedSecretEncryptionPublicKey {
+	pk, err := curve25519.X25519(sk[:], curve25519.Basepoint)
+	if err != nil {
+		panic(fmt.Sprintf("while deriving public key: %v", err))
+	}
+
+	var pkArray SharedSecretEncryptionPublicKey
+	copy(pkArray[:], pk)
+	return pkArray
+}
+
+// DecryptSharedSecret recovers the shared secret encrypted for oracle myIndex
+// in enc, using that oracle's secret key mySecretKey. It returns an error if
+// myIndex is out of range, or if the recovered plaintext does not match
+// enc.SharedSecretHash (e.g. because the ciphertext was tampered with, or
+// mySecretKey does not correspond to the public key the secret was encrypted
+// for).
+func DecryptSharedSecret(
+	enc SharedSecretEncryptions,
+	myIndex int,
+	mySecretKey [32]byte,
+) (*[SharedSecretSize]byte, error) {
+	if enc.Scheme != SchemeV1 {
+		return nil, fmt.Errorf("DecryptSharedSecret: unsupported scheme %d", enc.Scheme)
+	}
+	if myIndex < 0 || myIndex >= len(enc.Encryptions) {
+		return nil, fmt.Errorf("myIndex %d out of range [0,%d)", myIndex, len(enc.Encryptions))
+	}
+
+	// Perform Diffie-Hellman key exchange with the dealer's ephemeral public key
+	dhPoint, err := curve25519.X25519(mySecretKey[:], enc.DiffieHellmanPoint[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting sharedSecret: %w", err)
+	}
+
+	// Derive AES key from DH point
+	key := crypto.Keccak256(dhPoint)[:16]
+
+	// Decrypt shared secret with AES-128
+	sharedSecret := [SharedSecretSize]byte(aesDecryptBlock(key, [16]byte(enc.Encryptions[myIndex])))
+
+	if common.BytesToHash(crypto.Keccak256(sharedSecret[:])) != enc.SharedSecretHash {
+		return nil, fmt.Errorf("decrypted sharedSecret does not match SharedSecretHash")
+	}
+
+	return &sharedSecret, nil
+}
+
 func main() {
 	// Создаем или генерируем общий секрет (16 байт)
 	sharedSecret := [SharedSecretSize]byte{
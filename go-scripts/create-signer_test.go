@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestEncryptDecryptSharedSecretRoundTrip(t *testing.T) {
+	const n = 4
+	secretKeys := make([][32]byte, n)
+	publicKeys := make([]SharedSecretEncryptionPublicKey, n)
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(rand.Reader, secretKeys[i][:]); err != nil {
+			t.Fatalf("generating secret key %d: %v", i, err)
+		}
+		publicKeys[i] = DerivePublicKey(secretKeys[i])
+	}
+
+	var sharedSecret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, sharedSecret[:]); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc := EncryptSharedSecret(publicKeys, &sharedSecret, rand.Reader)
+
+	for i := 0; i < n; i++ {
+		got, err := DecryptSharedSecret(enc, i, secretKeys[i])
+		if err != nil {
+			t.Fatalf("DecryptSharedSecret(%d): unexpected error: %v", i, err)
+		}
+		if *got != sharedSecret {
+			t.Fatalf("DecryptSharedSecret(%d): got %x, want %x", i, *got, sharedSecret)
+		}
+	}
+}
+
+func TestDecryptSharedSecretWrongIndex(t *testing.T) {
+	var skA, skB [32]byte
+	if _, err := io.ReadFull(rand.Reader, skA[:]); err != nil {
+		t.Fatalf("generating skA: %v", err)
+	}
+	if _, err := io.ReadFull(rand.Reader, skB[:]); err != nil {
+		t.Fatalf("generating skB: %v", err)
+	}
+	publicKeys := []SharedSecretEncryptionPublicKey{DerivePublicKey(skA), DerivePublicKey(skB)}
+
+	var sharedSecret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, sharedSecret[:]); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc := EncryptSharedSecret(publicKeys, &sharedSecret, rand.Reader)
+
+	// Decrypting oracle B's slot with oracle A's secret key should either
+	// error out or, if it happens to produce a plaintext, fail the hash check.
+	got, err := DecryptSharedSecret(enc, 1, skA)
+	if err == nil {
+		t.Fatalf("DecryptSharedSecret with wrong index/key unexpectedly succeeded: %x", *got)
+	}
+
+	if _, err := DecryptSharedSecret(enc, len(enc.Encryptions), skA); err == nil {
+		t.Fatalf("DecryptSharedSecret with out-of-range index unexpectedly succeeded")
+	}
+}
+
+func TestDecryptSharedSecretTamperedCiphertext(t *testing.T) {
+	var sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, sk[:]); err != nil {
+		t.Fatalf("generating sk: %v", err)
+	}
+	publicKeys := []SharedSecretEncryptionPublicKey{DerivePublicKey(sk)}
+
+	var sharedSecret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, sharedSecret[:]); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc := EncryptSharedSecret(publicKeys, &sharedSecret, rand.Reader)
+	enc.Encryptions[0][0] ^= 0xff
+
+	if _, err := DecryptSharedSecret(enc, 0, sk); err == nil {
+		t.Fatalf("DecryptSharedSecret of tampered ciphertext unexpectedly succeeded")
+	}
+}
+
+func TestDecryptSharedSecretLowOrderPoint(t *testing.T) {
+	var sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, sk[:]); err != nil {
+		t.Fatalf("generating sk: %v", err)
+	}
+
+	var sharedSecret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, sharedSecret[:]); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc := EncryptSharedSecret([]SharedSecretEncryptionPublicKey{DerivePublicKey(sk)}, &sharedSecret, rand.Reader)
+
+	// curve25519.Basepoint * 0 is the identity, a classic low-order point;
+	// X25519 with any scalar should reject it rather than silently returning
+	// an all-zero shared point.
+	var lowOrderPoint [curve25519.PointSize]byte
+	enc.DiffieHellmanPoint = lowOrderPoint
+
+	if _, err := DecryptSharedSecret(enc, 0, sk); err == nil {
+		t.Fatalf("DecryptSharedSecret with low-order DH point unexpectedly succeeded")
+	}
+}
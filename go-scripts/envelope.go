@@ -0,0 +1,305 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// envelopeVersionTag prefixes the wire representation of every Envelope, so
+// a ciphertext can never be confused for some other protocol's token (the
+// PASETO "no ambiguity on the wire" property).
+const envelopeVersionTag = "v1.chainlink-sse."
+
+// envelopeHKDFInfoSuffix is appended to Header when deriving each recipient's
+// AEAD key, binding the key to the wire format/scheme the payload claims to
+// be (PASETO's pre-authentication encoding idea, applied to this envelope).
+const envelopeHKDFInfoSuffix = "chainlink-shared-secret-encryption-v1"
+
+// envelopeFormatV1 is the only wire format version byte Parse/UnmarshalBinary
+// currently accept.
+const envelopeFormatV1 = 1
+
+// EnvelopeHeader is the 4-byte scheme/version tag carried by every Envelope:
+// byte 0 is the wire format version, byte 1 is the Scheme the payload is
+// encrypted under, and bytes 2-3 are reserved (zero).
+type EnvelopeHeader [4]byte
+
+// NewEnvelopeHeader builds the version-1 header for the given Scheme.
+func NewEnvelopeHeader(scheme Scheme) EnvelopeHeader {
+	return EnvelopeHeader{envelopeFormatV1, byte(scheme), 0, 0}
+}
+
+// EnvelopeShare is one recipient's ChaCha20-Poly1305-sealed share, as stored
+// in an Envelope.
+type EnvelopeShare struct {
+	Nonce      [chacha20poly1305.NonceSize]byte
+	Ciphertext []byte
+}
+
+// Envelope is a versioned, self-describing, domain-separated alternative to
+// a bare SharedSecretEncryptions: it rejects unknown version tags on Parse,
+// and binds its Header and Footer into each recipient's AEAD tag, so neither
+// can be swapped without invalidating every share.
+type Envelope struct {
+	Header             EnvelopeHeader
+	DiffieHellmanPoint [curve25519.PointSize]byte
+	SharedSecretHash   common.Hash
+	Encryptions        []EnvelopeShare
+
+	// Footer is not required to recover the shared secret, but it is still
+	// covered by every recipient's AEAD associated data: it can carry
+	// unauthenticated-looking routing metadata (e.g. a committee ID) without
+	// actually being forgeable independently of the ciphertext.
+	Footer []byte
+}
+
+func envelopeAEADInfo(header EnvelopeHeader) []byte {
+	info := make([]byte, 0, len(header)+len(envelopeHKDFInfoSuffix))
+	info = append(info, header[:]...)
+	info = append(info, envelopeHKDFInfoSuffix...)
+	return info
+}
+
+func deriveEnvelopeKeyNonce(header EnvelopeHeader, dhPoint []byte) (key [chacha20poly1305.KeySize]byte, nonce [chacha20poly1305.NonceSize]byte, err error) {
+	kdf := hkdf.New(sha256.New, dhPoint, nil, envelopeAEADInfo(header))
+	if _, err := io.ReadFull(kdf, key[:]); err != nil {
+		return key, nonce, fmt.Errorf("deriving envelope AEAD key: %w", err)
+	}
+	if _, err := io.ReadFull(kdf, nonce[:]); err != nil {
+		return key, nonce, fmt.Errorf("deriving envelope AEAD nonce: %w", err)
+	}
+	return key, nonce, nil
+}
+
+// EncryptEnvelope builds a version-1 Envelope sealing sharedSecret to
+// publicKeys. footer is bound into every share's AEAD tag but is not
+// encrypted.
+func EncryptEnvelope(
+	publicKeys []SharedSecretEncryptionPublicKey,
+	sharedSecret []byte,
+	footer []byte,
+	rand io.Reader,
+) (*Envelope, error) {
+	var sk [32]byte
+	if _, err := io.ReadFull(rand, sk[:]); err != nil {
+		return nil, fmt.Errorf("could not produce entropy for encryption: %w", err)
+	}
+	dhBase := DerivePublicKey(sk)
+	header := NewEnvelopeHeader(SchemeAEAD)
+
+	shares := make([]EnvelopeShare, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		pkBytes := [32]byte(publicKey)
+
+		dhPoint, err := curve25519.X25519(sk[:], pkBytes[:])
+		if err != nil {
+			return nil, fmt.Errorf("while encrypting envelope: %w", err)
+		}
+
+		key, nonce, err := deriveEnvelopeKeyNonce(header, dhPoint)
+		if err != nil {
+			return nil, fmt.Errorf("while encrypting envelope: %w", err)
+		}
+
+		aead, err := chacha20poly1305.New(key[:])
+		if err != nil {
+			return nil, fmt.Errorf("while encrypting envelope: %w", err)
+		}
+
+		ciphertext := aead.Seal(nil, nonce[:], sharedSecret, footer)
+		shares = append(shares, EnvelopeShare{Nonce: nonce, Ciphertext: ciphertext})
+	}
+
+	return &Envelope{
+		Header:             header,
+		DiffieHellmanPoint: dhBase,
+		SharedSecretHash:   common.BytesToHash(crypto.Keccak256(sharedSecret)),
+		Encryptions:        shares,
+		Footer:             footer,
+	}, nil
+}
+
+// DecryptEnvelope recovers the shared secret sealed for oracle myIndex in e,
+// using that oracle's secret key.
+func DecryptEnvelope(e *Envelope, myIndex int, mySecretKey [32]byte) ([]byte, error) {
+	if e.Header[0] != envelopeFormatV1 {
+		return nil, fmt.Errorf("unsupported envelope format version %d", e.Header[0])
+	}
+	if Scheme(e.Header[1]) != SchemeAEAD {
+		return nil, fmt.Errorf("unsupported envelope scheme %d", e.Header[1])
+	}
+	if myIndex < 0 || myIndex >= len(e.Encryptions) {
+		return nil, fmt.Errorf("myIndex %d out of range [0,%d)", myIndex, len(e.Encryptions))
+	}
+
+	dhPoint, err := curve25519.X25519(mySecretKey[:], e.DiffieHellmanPoint[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting envelope: %w", err)
+	}
+
+	key, nonce, err := deriveEnvelopeKeyNonce(e.Header, dhPoint)
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting envelope: %w", err)
+	}
+
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting envelope: %w", err)
+	}
+
+	share := e.Encryptions[myIndex]
+	if nonce != share.Nonce {
+		return nil, fmt.Errorf("stored nonce does not match derived nonce")
+	}
+
+	sharedSecret, err := aead.Open(nil, nonce[:], share.Ciphertext, e.Footer)
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting envelope: %w", err)
+	}
+
+	if common.BytesToHash(crypto.Keccak256(sharedSecret)) != e.SharedSecretHash {
+		return nil, fmt.Errorf("decrypted sharedSecret does not match SharedSecretHash")
+	}
+	return sharedSecret, nil
+}
+
+// MarshalBinary encodes e as header || dhPoint || hash || n || encryptions... || footer,
+// with each variable-length field length-prefixed.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if len(e.Encryptions) > 0xFFFF {
+		return nil, fmt.Errorf("too many encryptions to encode: %d", len(e.Encryptions))
+	}
+
+	buf := make([]byte, 0, 4+curve25519.PointSize+common.HashLength+2+4+len(e.Footer))
+	buf = append(buf, e.Header[:]...)
+	buf = append(buf, e.DiffieHellmanPoint[:]...)
+	buf = append(buf, e.SharedSecretHash[:]...)
+
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(e.Encryptions)))
+	buf = append(buf, n[:]...)
+
+	for _, share := range e.Encryptions {
+		buf = append(buf, share.Nonce[:]...)
+
+		var ctLen [4]byte
+		binary.BigEndian.PutUint32(ctLen[:], uint32(len(share.Ciphertext)))
+		buf = append(buf, ctLen[:]...)
+		buf = append(buf, share.Ciphertext...)
+	}
+
+	var footerLen [4]byte
+	binary.BigEndian.PutUint32(footerLen[:], uint32(len(e.Footer)))
+	buf = append(buf, footerLen[:]...)
+	buf = append(buf, e.Footer...)
+
+	return buf, nil
+}
+
+// UnmarshalBinary is the inverse of MarshalBinary.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	const headerLen = 4
+	const fixedLen = headerLen + curve25519.PointSize + common.HashLength + 2
+	if len(data) < fixedLen {
+		return fmt.Errorf("envelope too short: %d bytes", len(data))
+	}
+
+	var header EnvelopeHeader
+	copy(header[:], data[:headerLen])
+	if header[0] != envelopeFormatV1 {
+		return fmt.Errorf("unsupported envelope format version %d", header[0])
+	}
+
+	offset := headerLen
+	var dhPoint [curve25519.PointSize]byte
+	copy(dhPoint[:], data[offset:offset+curve25519.PointSize])
+	offset += curve25519.PointSize
+
+	var hash common.Hash
+	copy(hash[:], data[offset:offset+common.HashLength])
+	offset += common.HashLength
+
+	n := int(binary.BigEndian.Uint16(data[offset : offset+2]))
+	offset += 2
+
+	shares := make([]EnvelopeShare, 0, n)
+	for i := 0; i < n; i++ {
+		if len(data) < offset+chacha20poly1305.NonceSize+4 {
+			return fmt.Errorf("truncated envelope at share %d", i)
+		}
+		var share EnvelopeShare
+		copy(share.Nonce[:], data[offset:offset+chacha20poly1305.NonceSize])
+		offset += chacha20poly1305.NonceSize
+
+		ctLen := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if ctLen < 0 || len(data) < offset+ctLen {
+			return fmt.Errorf("truncated envelope ciphertext at share %d", i)
+		}
+		share.Ciphertext = append([]byte(nil), data[offset:offset+ctLen]...)
+		offset += ctLen
+
+		shares = append(shares, share)
+	}
+
+	if len(data) < offset+4 {
+		return fmt.Errorf("truncated envelope footer length")
+	}
+	footerLen := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+	offset += 4
+	if footerLen < 0 || len(data) < offset+footerLen {
+		return fmt.Errorf("truncated envelope footer")
+	}
+	footer := append([]byte(nil), data[offset:offset+footerLen]...)
+	offset += footerLen
+
+	if offset != len(data) {
+		return fmt.Errorf("trailing data after envelope footer")
+	}
+
+	e.Header = header
+	e.DiffieHellmanPoint = dhPoint
+	e.SharedSecretHash = hash
+	e.Encryptions = shares
+	e.Footer = footer
+	return nil
+}
+
+// Marshal returns e's full wire representation:
+// "v1.chainlink-sse.<base64url(header||dhPoint||hash||n||encryptions...||footer)>".
+func (e *Envelope) Marshal() (string, error) {
+	payload, err := e.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return envelopeVersionTag + base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// Parse decodes a string produced by Envelope.Marshal, rejecting anything
+// that doesn't carry a recognized version tag.
+func Parse(s string) (*Envelope, error) {
+	if !strings.HasPrefix(s, envelopeVersionTag) {
+		return nil, fmt.Errorf("unrecognized envelope version tag")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, envelopeVersionTag))
+	if err != nil {
+		return nil, fmt.Errorf("decoding envelope: %w", err)
+	}
+
+	var e Envelope
+	if err := e.UnmarshalBinary(payload); err != nil {
+		return nil, fmt.Errorf("decoding envelope: %w", err)
+	}
+	return &e, nil
+}
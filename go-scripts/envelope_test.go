@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	const n = 3
+	secretKeys := make([][32]byte, n)
+	publicKeys := make([]SharedSecretEncryptionPublicKey, n)
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(rand.Reader, secretKeys[i][:]); err != nil {
+			t.Fatalf("generating secret key %d: %v", i, err)
+		}
+		publicKeys[i] = DerivePublicKey(secretKeys[i])
+	}
+
+	sharedSecret := make([]byte, SharedSecretSize)
+	if _, err := io.ReadFull(rand.Reader, sharedSecret); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+	footer := []byte("committee-42")
+
+	env, err := EncryptEnvelope(publicKeys, sharedSecret, footer, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptEnvelope: unexpected error: %v", err)
+	}
+
+	wire, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(wire, "v1.chainlink-sse.") {
+		t.Fatalf("Marshal: wire format missing version tag: %q", wire)
+	}
+
+	parsed, err := Parse(wire)
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		got, err := DecryptEnvelope(parsed, i, secretKeys[i])
+		if err != nil {
+			t.Fatalf("DecryptEnvelope(%d): unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(got, sharedSecret) {
+			t.Fatalf("DecryptEnvelope(%d): got %x, want %x", i, got, sharedSecret)
+		}
+	}
+}
+
+func TestParseRejectsUnknownVersionTag(t *testing.T) {
+	if _, err := Parse("v2.chainlink-sse.AAAA"); err == nil {
+		t.Fatalf("Parse accepted an unrecognized version tag")
+	}
+	if _, err := Parse("not-an-envelope-at-all"); err == nil {
+		t.Fatalf("Parse accepted a string with no version tag")
+	}
+}
+
+func TestParseRejectsTruncatedPayload(t *testing.T) {
+	if _, err := Parse("v1.chainlink-sse." + "AAAA"); err == nil {
+		t.Fatalf("Parse accepted a truncated payload")
+	}
+}
+
+func TestDecryptEnvelopeRejectsTamperedFooter(t *testing.T) {
+	var sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, sk[:]); err != nil {
+		t.Fatalf("generating secret key: %v", err)
+	}
+	sharedSecret := make([]byte, SharedSecretSize)
+	if _, err := io.ReadFull(rand.Reader, sharedSecret); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	env, err := EncryptEnvelope([]SharedSecretEncryptionPublicKey{DerivePublicKey(sk)}, sharedSecret, []byte("route-a"), rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptEnvelope: unexpected error: %v", err)
+	}
+
+	// Swapping the footer post hoc should invalidate the AEAD tag, even
+	// though the footer is not itself encrypted.
+	env.Footer = []byte("route-b")
+
+	if _, err := DecryptEnvelope(env, 0, sk); err == nil {
+		t.Fatalf("DecryptEnvelope with a swapped footer unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"fmt"
+	"io"
+
+	"filippo.io/edwards25519"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"golang.org/x/crypto/curve25519"
+)
+
+// KeyType identifies which curve/algorithm a RecipientKey is expressed on,
+// so a committee of oracles need not all be keyed on the same curve.
+type KeyType byte
+
+const (
+	KeyTypeX25519 KeyType = iota
+	KeyTypeEd25519
+	KeyTypeSecp256k1
+)
+
+// RecipientKey is a public key that a shared secret can be encrypted to,
+// regardless of which curve the corresponding private key lives on. This
+// mirrors the libp2p-crypto approach of consolidating multiple key
+// algorithms behind a single interface.
+type RecipientKey interface {
+	Type() KeyType
+	// Encrypt seals plaintext for the holder of this key's corresponding
+	// private key. The returned blob is self-contained (it embeds whatever
+	// ephemeral key material the scheme needs) and opaque to callers; only a
+	// Decrypt routine for the matching KeyType can open it.
+	Encrypt(rand io.Reader, plaintext []byte) ([]byte, error)
+}
+
+// EncryptedShare is one oracle's encrypted share of a shared secret, tagged
+// with the RecipientKey scheme that produced it.
+type EncryptedShare struct {
+	KeyType    KeyType
+	Ciphertext []byte
+}
+
+// EncryptSharedSecretMixed encrypts sharedSecret to a heterogeneous set of
+// RecipientKeys under SchemeMixed, so a committee with oracles keyed on
+// different curves can each recover it with the Decrypt routine matching
+// their own key's KeyType. This is kept distinct from EncryptSharedSecret
+// rather than overloading it, since that name is already taken by the
+// legacy single-block AES-ECB path over []SharedSecretEncryptionPublicKey
+// (create-signer.go), which chunk0-2 preserves for existing callers.
+func EncryptSharedSecretMixed(
+	recipients []RecipientKey,
+	sharedSecret []byte,
+	rand io.Reader,
+) (SharedSecretEncryptions, error) {
+	shares := make([]EncryptedShare, 0, len(recipients))
+	for i, recipient := range recipients {
+		ciphertext, err := recipient.Encrypt(rand, sharedSecret)
+		if err != nil {
+			return SharedSecretEncryptions{}, fmt.Errorf("encrypting to recipient %d: %w", i, err)
+		}
+		shares = append(shares, EncryptedShare{KeyType: recipient.Type(), Ciphertext: ciphertext})
+	}
+
+	return SharedSecretEncryptions{
+		Scheme:           SchemeMixed,
+		SharedSecretHash: common.BytesToHash(crypto.Keccak256(sharedSecret)),
+		MixedEncryptions: shares,
+	}, nil
+}
+
+// Type implements RecipientKey for the pre-existing X25519 public key type.
+func (pk SharedSecretEncryptionPublicKey) Type() KeyType { return KeyTypeX25519 }
+
+// Encrypt implements RecipientKey by performing a fresh ephemeral X25519
+// Diffie-Hellman exchange with pk and sealing plaintext with the same
+// Keccak256-derived AES-128 block cipher as EncryptSharedSecret. plaintext
+// must be exactly SharedSecretSize bytes. The returned blob is
+// ephemeralPublicKey || ciphertext.
+func (pk SharedSecretEncryptionPublicKey) Encrypt(rand io.Reader, plaintext []byte) ([]byte, error) {
+	if len(plaintext) != SharedSecretSize {
+		return nil, fmt.Errorf("X25519 RecipientKey only supports %d-byte plaintexts, got %d", SharedSecretSize, len(plaintext))
+	}
+
+	var sk [32]byte
+	if _, err := io.ReadFull(rand, sk[:]); err != nil {
+		return nil, fmt.Errorf("could not produce entropy for encryption: %w", err)
+	}
+	ephemeralPublicKey := DerivePublicKey(sk)
+
+	dhPoint, err := curve25519.X25519(sk[:], pk[:])
+	if err != nil {
+		return nil, fmt.Errorf("while encrypting to X25519 recipient: %w", err)
+	}
+	key := crypto.Keccak256(dhPoint)[:16]
+	ciphertext := aesEncryptBlock(key, plaintext)
+
+	out := make([]byte, 0, len(ephemeralPublicKey)+len(ciphertext))
+	out = append(out, ephemeralPublicKey[:]...)
+	out = append(out, ciphertext[:]...)
+	return out, nil
+}
+
+func decryptX25519Ciphertext(ciphertext []byte, mySecretKey [32]byte) ([]byte, error) {
+	if len(ciphertext) != curve25519.PointSize+16 {
+		return nil, fmt.Errorf("malformed X25519 share: want %d bytes, got %d", curve25519.PointSize+16, len(ciphertext))
+	}
+
+	var ephemeralPublicKey [32]byte
+	copy(ephemeralPublicKey[:], ciphertext[:curve25519.PointSize])
+
+	dhPoint, err := curve25519.X25519(mySecretKey[:], ephemeralPublicKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting X25519 share: %w", err)
+	}
+	key := crypto.Keccak256(dhPoint)[:16]
+
+	var block [16]byte
+	copy(block[:], ciphertext[curve25519.PointSize:])
+	plaintext := aesDecryptBlock(key, block)
+	return plaintext[:], nil
+}
+
+// DecryptSharedSecretMixedX25519 recovers the shared secret from the
+// SchemeMixed share at myIndex, which must have been encrypted to an X25519
+// RecipientKey.
+func DecryptSharedSecretMixedX25519(enc SharedSecretEncryptions, myIndex int, mySecretKey [32]byte) ([]byte, error) {
+	share, err := mixedShare(enc, myIndex, KeyTypeX25519)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decryptX25519Ciphertext(share.Ciphertext, mySecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return checkSharedSecretHash(enc, plaintext)
+}
+
+// Ed25519RecipientKey is an oracle's Ed25519 signing public key, used as an
+// encryption recipient by converting it to its birationally equivalent
+// X25519 point (via filippo.io/edwards25519) and reusing the X25519 DH/AES
+// path above.
+type Ed25519RecipientKey ed25519.PublicKey
+
+func (pk Ed25519RecipientKey) Type() KeyType { return KeyTypeEd25519 }
+
+func (pk Ed25519RecipientKey) toX25519() (SharedSecretEncryptionPublicKey, error) {
+	if len(pk) != ed25519.PublicKeySize {
+		return SharedSecretEncryptionPublicKey{}, fmt.Errorf("ed25519 public key must be %d bytes, got %d", ed25519.PublicKeySize, len(pk))
+	}
+
+	point, err := (&edwards25519.Point{}).SetBytes(pk)
+	if err != nil {
+		return SharedSecretEncryptionPublicKey{}, fmt.Errorf("invalid ed25519 public key: %w", err)
+	}
+
+	var out SharedSecretEncryptionPublicKey
+	copy(out[:], point.BytesMontgomery())
+	return out, nil
+}
+
+func (pk Ed25519RecipientKey) Encrypt(rand io.Reader, plaintext []byte) ([]byte, error) {
+	x25519Key, err := pk.toX25519()
+	if err != nil {
+		return nil, err
+	}
+	return x25519Key.Encrypt(rand, plaintext)
+}
+
+// ed25519PrivateKeyToX25519 converts an Ed25519 private key to its
+// corresponding X25519 scalar, using the standard seed-hash-and-clamp
+// construction (as used by e.g. libsodium's crypto_sign_ed25519_sk_to_curve25519).
+func ed25519PrivateKeyToX25519(sk ed25519.PrivateKey) [32]byte {
+	h := sha512.Sum512(sk.Seed())
+	h[0] &= 248
+	h[31] &= 127
+	h[31] |= 64
+
+	var out [32]byte
+	copy(out[:], h[:32])
+	return out
+}
+
+// DecryptSharedSecretMixedEd25519 recovers the shared secret from the
+// SchemeMixed share at myIndex, which must have been encrypted to an
+// Ed25519RecipientKey.
+func DecryptSharedSecretMixedEd25519(enc SharedSecretEncryptions, myIndex int, mySecretKey ed25519.PrivateKey) ([]byte, error) {
+	share, err := mixedShare(enc, myIndex, KeyTypeEd25519)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decryptX25519Ciphertext(share.Ciphertext, ed25519PrivateKeyToX25519(mySecretKey))
+	if err != nil {
+		return nil, err
+	}
+	return checkSharedSecretHash(enc, plaintext)
+}
+
+// Secp256k1RecipientKey is an oracle's secp256k1 public key, encrypted to via
+// ECIES (ephemeral key + KDF + AES-128-CTR + HMAC-SHA256 tag), mirroring
+// go-ethereum's crypto/ecies package, which is reused directly here.
+type Secp256k1RecipientKey ecdsa.PublicKey
+
+func (pk *Secp256k1RecipientKey) Type() KeyType { return KeyTypeSecp256k1 }
+
+func (pk *Secp256k1RecipientKey) Encrypt(rand io.Reader, plaintext []byte) ([]byte, error) {
+	ciphertext, err := ecies.Encrypt(rand, ecies.ImportECDSAPublic((*ecdsa.PublicKey)(pk)), plaintext, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("while encrypting to secp256k1 recipient: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// DecryptSharedSecretMixedSecp256k1 recovers the shared secret from the
+// SchemeMixed share at myIndex, which must have been encrypted to a
+// Secp256k1RecipientKey.
+func DecryptSharedSecretMixedSecp256k1(enc SharedSecretEncryptions, myIndex int, mySecretKey *ecdsa.PrivateKey) ([]byte, error) {
+	share, err := mixedShare(enc, myIndex, KeyTypeSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := ecies.ImportECDSA(mySecretKey).Decrypt(share.Ciphertext, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting secp256k1 share: %w", err)
+	}
+	return checkSharedSecretHash(enc, plaintext)
+}
+
+func mixedShare(enc SharedSecretEncryptions, myIndex int, want KeyType) (EncryptedShare, error) {
+	if enc.Scheme != SchemeMixed {
+		return EncryptedShare{}, fmt.Errorf("unsupported scheme %d", enc.Scheme)
+	}
+	if myIndex < 0 || myIndex >= len(enc.MixedEncryptions) {
+		return EncryptedShare{}, fmt.Errorf("myIndex %d out of range [0,%d)", myIndex, len(enc.MixedEncryptions))
+	}
+	share := enc.MixedEncryptions[myIndex]
+	if share.KeyType != want {
+		return EncryptedShare{}, fmt.Errorf("share at index %d has KeyType %d, want %d", myIndex, share.KeyType, want)
+	}
+	return share, nil
+}
+
+func checkSharedSecretHash(enc SharedSecretEncryptions, plaintext []byte) ([]byte, error) {
+	if common.BytesToHash(crypto.Keccak256(plaintext)) != enc.SharedSecretHash {
+		return nil, fmt.Errorf("decrypted sharedSecret does not match SharedSecretHash")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEncryptSharedSecretMixedRoundTrip(t *testing.T) {
+	sharedSecret := make([]byte, SharedSecretSize)
+	if _, err := io.ReadFull(rand.Reader, sharedSecret); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	var x25519Sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, x25519Sk[:]); err != nil {
+		t.Fatalf("generating x25519 secret key: %v", err)
+	}
+	x25519Pub := DerivePublicKey(x25519Sk)
+
+	ed25519Pub, ed25519Priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ed25519 key: %v", err)
+	}
+
+	secp256k1Priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generating secp256k1 key: %v", err)
+	}
+
+	recipients := []RecipientKey{
+		x25519Pub,
+		Ed25519RecipientKey(ed25519Pub),
+		(*Secp256k1RecipientKey)(&secp256k1Priv.PublicKey),
+	}
+
+	enc, err := EncryptSharedSecretMixed(recipients, sharedSecret, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptSharedSecretMixed: unexpected error: %v", err)
+	}
+
+	got, err := DecryptSharedSecretMixedX25519(enc, 0, x25519Sk)
+	if err != nil {
+		t.Fatalf("DecryptSharedSecretMixedX25519: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, sharedSecret) {
+		t.Fatalf("DecryptSharedSecretMixedX25519: got %x, want %x", got, sharedSecret)
+	}
+
+	got, err = DecryptSharedSecretMixedEd25519(enc, 1, ed25519Priv)
+	if err != nil {
+		t.Fatalf("DecryptSharedSecretMixedEd25519: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, sharedSecret) {
+		t.Fatalf("DecryptSharedSecretMixedEd25519: got %x, want %x", got, sharedSecret)
+	}
+
+	got, err = DecryptSharedSecretMixedSecp256k1(enc, 2, secp256k1Priv)
+	if err != nil {
+		t.Fatalf("DecryptSharedSecretMixedSecp256k1: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, sharedSecret) {
+		t.Fatalf("DecryptSharedSecretMixedSecp256k1: got %x, want %x", got, sharedSecret)
+	}
+}
+
+func TestDecryptSharedSecretMixedWrongKeyType(t *testing.T) {
+	sharedSecret := make([]byte, SharedSecretSize)
+	if _, err := io.ReadFull(rand.Reader, sharedSecret); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	var x25519Sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, x25519Sk[:]); err != nil {
+		t.Fatalf("generating x25519 secret key: %v", err)
+	}
+
+	enc, err := EncryptSharedSecretMixed([]RecipientKey{DerivePublicKey(x25519Sk)}, sharedSecret, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptSharedSecretMixed: unexpected error: %v", err)
+	}
+
+	var ecdsaPriv *ecdsa.PrivateKey
+	if ecdsaPriv, err = crypto.GenerateKey(); err != nil {
+		t.Fatalf("generating secp256k1 key: %v", err)
+	}
+	if _, err := DecryptSharedSecretMixedSecp256k1(enc, 0, ecdsaPriv); err == nil {
+		t.Fatalf("DecryptSharedSecretMixedSecp256k1 against an X25519 share unexpectedly succeeded")
+	}
+}
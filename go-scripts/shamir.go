@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// gfExp and gfLog are the antilog/log tables for GF(2^8) under the AES
+// irreducible polynomial x^8+x^4+x^3+x+1 (0x11B), used to make gmul a pair of
+// table lookups instead of carry-less multiplication. They are built from
+// generator 3, which (unlike 2) has multiplicative order 255 under this
+// polynomial, i.e. it actually generates the whole field.
+var gfExp [510]byte
+var gfLog [256]byte
+
+func init() {
+	p := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = p
+		gfLog[p] = byte(i)
+
+		// p *= 3 (= p*2 XOR p), reducing mod 0x11B (low byte 0x1B) on overflow
+		hiBitSet := p & 0x80
+		p <<= 1
+		if hiBitSet != 0 {
+			p ^= 0x1B
+		}
+		p ^= gfExp[i]
+	}
+	for i := 255; i < 510; i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+// gadd is addition (and subtraction) in GF(2^8), which is XOR.
+func gadd(a, b byte) byte {
+	return a ^ b
+}
+
+// gmul is multiplication in GF(2^8), via the log/antilog tables above.
+func gmul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gdiv is division in GF(2^8); b must be non-zero.
+func gdiv(a, b byte) byte {
+	if b == 0 {
+		panic("division by zero in GF(2^8)")
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])-int(gfLog[b])+255)%255]
+}
+
+// gfPolyEval evaluates the polynomial with the given coefficients (lowest
+// degree first) at x, via Horner's method.
+func gfPolyEval(coeffs []byte, x byte) byte {
+	result := byte(0)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result = gadd(gmul(result, x), coeffs[i])
+	}
+	return result
+}
+
+// shamirSplit splits secret into n shares with threshold t, byte-wise in
+// GF(2^8): for each byte, t-1 random coefficients are chosen and the
+// resulting degree-(t-1) polynomial (with the secret byte as the constant
+// term) is evaluated at x = 1..n. Shares are returned in x-coordinate order,
+// i.e. shares[i] is the share for x = i+1.
+func shamirSplit(secret [SharedSecretSize]byte, t, n uint8, rand io.Reader) ([][SharedSecretSize]byte, error) {
+	if t == 0 || t > n {
+		return nil, fmt.Errorf("threshold t (%d) must be in [1,%d]", t, n)
+	}
+
+	coeffs := make([][]byte, SharedSecretSize)
+	for j := 0; j < SharedSecretSize; j++ {
+		coeffs[j] = make([]byte, t)
+		coeffs[j][0] = secret[j]
+		if t > 1 {
+			if _, err := io.ReadFull(rand, coeffs[j][1:]); err != nil {
+				return nil, fmt.Errorf("could not produce entropy for Shamir split: %w", err)
+			}
+		}
+	}
+
+	shares := make([][SharedSecretSize]byte, n)
+	for i := uint8(0); i < n; i++ {
+		x := i + 1
+		for j := 0; j < SharedSecretSize; j++ {
+			shares[i][j] = gfPolyEval(coeffs[j], x)
+		}
+	}
+	return shares, nil
+}
+
+// shamirCombine reconstructs the secret from a set of (x-coordinate, share)
+// pairs via Lagrange interpolation at x=0, byte-wise in GF(2^8). The caller
+// is responsible for ensuring at least the original threshold's worth of
+// shares are present; fewer shares silently reconstruct the wrong secret,
+// just as in any Shamir scheme.
+func shamirCombine(shares map[uint8][SharedSecretSize]byte) [SharedSecretSize]byte {
+	xs := make([]uint8, 0, len(shares))
+	for x := range shares {
+		xs = append(xs, x)
+	}
+
+	var secret [SharedSecretSize]byte
+	for j := 0; j < SharedSecretSize; j++ {
+		var acc byte
+		for _, xi := range xs {
+			num, den := byte(1), byte(1)
+			for _, xm := range xs {
+				if xm == xi {
+					continue
+				}
+				// 0 - xm == xm, and xi - xm == xi ^ xm, since GF(2^8) has
+				// characteristic 2.
+				num = gmul(num, xm)
+				den = gmul(den, gadd(xi, xm))
+			}
+			term := gmul(shares[xi][j], gdiv(num, den))
+			acc = gadd(acc, term)
+		}
+		secret[j] = acc
+	}
+	return secret
+}
+
+// EncryptSharedSecretThreshold splits secret into a t-of-n Shamir sharing and
+// encrypts share i to pubs[i], so that any t of the n oracles can recover
+// secret via CombineShares without every oracle needing to hold the full
+// secret.
+func EncryptSharedSecretThreshold(
+	pubs []SharedSecretEncryptionPublicKey,
+	secret *[SharedSecretSize]byte,
+	t, n uint8,
+	rand io.Reader,
+) (SharedSecretEncryptions, error) {
+	if int(n) != len(pubs) {
+		return SharedSecretEncryptions{}, fmt.Errorf("n (%d) must match len(pubs) (%d)", n, len(pubs))
+	}
+
+	shares, err := shamirSplit(*secret, t, n, rand)
+	if err != nil {
+		return SharedSecretEncryptions{}, err
+	}
+
+	encryptedShares := make([]EncryptedShare, n)
+	shareIndex := make([]uint8, n)
+	for i := uint8(0); i < n; i++ {
+		ciphertext, err := pubs[i].Encrypt(rand, shares[i][:])
+		if err != nil {
+			return SharedSecretEncryptions{}, fmt.Errorf("encrypting share %d: %w", i, err)
+		}
+		encryptedShares[i] = EncryptedShare{KeyType: KeyTypeX25519, Ciphertext: ciphertext}
+		shareIndex[i] = i + 1
+	}
+
+	return SharedSecretEncryptions{
+		Scheme:           SchemeThreshold,
+		SharedSecretHash: common.BytesToHash(crypto.Keccak256(secret[:])),
+		MixedEncryptions: encryptedShares,
+		Threshold:        t,
+		ShareIndex:       shareIndex,
+	}, nil
+}
+
+// DecryptThresholdShare recovers oracle myIndex's raw Shamir share (and its
+// x-coordinate) from a SharedSecretEncryptions produced by
+// EncryptSharedSecretThreshold. The returned share is an input to
+// CombineShares, not the shared secret itself.
+func DecryptThresholdShare(
+	enc SharedSecretEncryptions,
+	myIndex int,
+	mySecretKey [32]byte,
+) (shareIndex uint8, share [SharedSecretSize]byte, err error) {
+	if enc.Scheme != SchemeThreshold {
+		return 0, share, fmt.Errorf("unsupported scheme %d", enc.Scheme)
+	}
+	if myIndex < 0 || myIndex >= len(enc.MixedEncryptions) {
+		return 0, share, fmt.Errorf("myIndex %d out of range [0,%d)", myIndex, len(enc.MixedEncryptions))
+	}
+
+	plaintext, err := decryptX25519Ciphertext(enc.MixedEncryptions[myIndex].Ciphertext, mySecretKey)
+	if err != nil {
+		return 0, share, err
+	}
+	if len(plaintext) != SharedSecretSize {
+		return 0, share, fmt.Errorf("decrypted share has wrong length: got %d, want %d", len(plaintext), SharedSecretSize)
+	}
+	copy(share[:], plaintext)
+	return enc.ShareIndex[myIndex], share, nil
+}
+
+// CombineShares reconstructs the shared secret from a set of decrypted
+// Shamir shares (as returned by DecryptThresholdShare, keyed by their
+// x-coordinate), and checks the result against SharedSecretHash. The caller
+// must supply at least enc.Threshold shares.
+func (enc SharedSecretEncryptions) CombineShares(shares map[uint8][SharedSecretSize]byte) ([SharedSecretSize]byte, error) {
+	var zero [SharedSecretSize]byte
+	if enc.Scheme != SchemeThreshold {
+		return zero, fmt.Errorf("unsupported scheme %d", enc.Scheme)
+	}
+	if len(shares) < int(enc.Threshold) {
+		return zero, fmt.Errorf("need at least %d shares to reconstruct, got %d", enc.Threshold, len(shares))
+	}
+
+	secret := shamirCombine(shares)
+	if common.BytesToHash(crypto.Keccak256(secret[:])) != enc.SharedSecretHash {
+		return zero, fmt.Errorf("reconstructed sharedSecret does not match SharedSecretHash")
+	}
+	return secret, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+func TestGF256MulDivRoundTrip(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			product := gmul(byte(a), byte(b))
+			if got := gdiv(product, byte(b)); got != byte(a) {
+				t.Fatalf("gdiv(gmul(%d,%d), %d) = %d, want %d", a, b, b, got, a)
+			}
+		}
+	}
+}
+
+func TestShamirSplitCombineRoundTrip(t *testing.T) {
+	var secret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, secret[:]); err != nil {
+		t.Fatalf("generating secret: %v", err)
+	}
+
+	const t_, n = 3, 5
+	shares, err := shamirSplit(secret, t_, n, rand.Reader)
+	if err != nil {
+		t.Fatalf("shamirSplit: unexpected error: %v", err)
+	}
+
+	// Any subset of t_ shares should reconstruct the secret.
+	subsets := [][]uint8{{0, 1, 2}, {1, 3, 4}, {0, 2, 4}}
+	for _, subset := range subsets {
+		combined := map[uint8][SharedSecretSize]byte{}
+		for _, i := range subset {
+			combined[i+1] = shares[i]
+		}
+		got := shamirCombine(combined)
+		if got != secret {
+			t.Fatalf("shamirCombine(%v): got %x, want %x", subset, got, secret)
+		}
+	}
+}
+
+func TestEncryptSharedSecretThresholdRoundTrip(t *testing.T) {
+	const threshold, n = 2, 3
+	secretKeys := make([][32]byte, n)
+	pubs := make([]SharedSecretEncryptionPublicKey, n)
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(rand.Reader, secretKeys[i][:]); err != nil {
+			t.Fatalf("generating secret key %d: %v", i, err)
+		}
+		pubs[i] = DerivePublicKey(secretKeys[i])
+	}
+
+	var secret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, secret[:]); err != nil {
+		t.Fatalf("generating secret: %v", err)
+	}
+
+	enc, err := EncryptSharedSecretThreshold(pubs, &secret, threshold, n, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptSharedSecretThreshold: unexpected error: %v", err)
+	}
+
+	// Oracles 0 and 1 (a quorum of `threshold`) should be able to reconstruct.
+	collected := map[uint8][SharedSecretSize]byte{}
+	for _, i := range []int{0, 1} {
+		idx, share, err := DecryptThresholdShare(enc, i, secretKeys[i])
+		if err != nil {
+			t.Fatalf("DecryptThresholdShare(%d): unexpected error: %v", i, err)
+		}
+		collected[idx] = share
+	}
+
+	got, err := enc.CombineShares(collected)
+	if err != nil {
+		t.Fatalf("CombineShares: unexpected error: %v", err)
+	}
+	if got != secret {
+		t.Fatalf("CombineShares: got %x, want %x", got, secret)
+	}
+}
+
+func TestCombineSharesBelowThreshold(t *testing.T) {
+	const threshold, n = 3, 4
+	pubs := make([]SharedSecretEncryptionPublicKey, n)
+	for i := 0; i < n; i++ {
+		var sk [32]byte
+		if _, err := io.ReadFull(rand.Reader, sk[:]); err != nil {
+			t.Fatalf("generating secret key %d: %v", i, err)
+		}
+		pubs[i] = DerivePublicKey(sk)
+	}
+
+	var secret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, secret[:]); err != nil {
+		t.Fatalf("generating secret: %v", err)
+	}
+
+	enc, err := EncryptSharedSecretThreshold(pubs, &secret, threshold, n, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptSharedSecretThreshold: unexpected error: %v", err)
+	}
+
+	if _, err := enc.CombineShares(map[uint8][SharedSecretSize]byte{1: {}, 2: {}}); err == nil {
+		t.Fatalf("CombineShares with fewer than Threshold shares unexpectedly succeeded")
+	}
+}
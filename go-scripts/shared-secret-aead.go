@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// encryptedSharedSecretAEAD is one oracle's ChaCha20-Poly1305-sealed share of
+// the shared secret, under SchemeAEAD.
+type encryptedSharedSecretAEAD struct {
+	Nonce [chacha20poly1305.NonceSize]byte
+
+	// Ciphertext with the Poly1305 tag appended, as returned by
+	// cipher.AEAD.Seal.
+	Ciphertext []byte
+}
+
+// deriveAEADKeyNonce derives the ChaCha20-Poly1305 key and deterministic
+// nonce for one recipient, via HKDF-SHA256 over the X25519 shared point,
+// bound to the sender and recipient public keys so that a key/nonce pair
+// cannot be replayed across a different (sender, recipient) pairing.
+func deriveAEADKeyNonce(dhPoint, senderPub, recipientPub []byte) (key [chacha20poly1305.KeySize]byte, nonce [chacha20poly1305.NonceSize]byte, err error) {
+	info := make([]byte, 0, len(dhPoint)+len(senderPub)+len(recipientPub))
+	info = append(info, dhPoint...)
+	info = append(info, senderPub...)
+	info = append(info, recipientPub...)
+
+	kdf := hkdf.New(sha256.New, dhPoint, nil, info)
+	if _, err := io.ReadFull(kdf, key[:]); err != nil {
+		return key, nonce, fmt.Errorf("deriving AEAD key: %w", err)
+	}
+	if _, err := io.ReadFull(kdf, nonce[:]); err != nil {
+		return key, nonce, fmt.Errorf("deriving AEAD nonce: %w", err)
+	}
+	return key, nonce, nil
+}
+
+// EncryptSharedSecretAEAD creates SharedSecretEncryptions from a set of
+// public keys and a shared secret, using SchemeAEAD: per-recipient keys are
+// derived with HKDF-SHA256 and the secret is sealed with ChaCha20-Poly1305.
+// Unlike EncryptSharedSecret, sharedSecret is not limited to SharedSecretSize
+// bytes.
+func EncryptSharedSecretAEAD(
+	publicKeys []SharedSecretEncryptionPublicKey,
+	sharedSecret []byte,
+	rand io.Reader,
+) (SharedSecretEncryptions, error) {
+	// Generate ephemeral secret key
+	var sk [32]byte
+	if _, err := io.ReadFull(rand, sk[:]); err != nil {
+		return SharedSecretEncryptions{}, fmt.Errorf("could not produce entropy for encryption: %w", err)
+	}
+
+	pkArray := DerivePublicKey(sk)
+
+	encryptedSharedSecrets := make([]encryptedSharedSecretAEAD, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		pkBytes := [32]byte(publicKey)
+
+		// Perform Diffie-Hellman key exchange
+		dhPoint, err := curve25519.X25519(sk[:], pkBytes[:])
+		if err != nil {
+			return SharedSecretEncryptions{}, fmt.Errorf("while encrypting sharedSecret: %w", err)
+		}
+
+		key, nonce, err := deriveAEADKeyNonce(dhPoint, pkArray[:], pkBytes[:])
+		if err != nil {
+			return SharedSecretEncryptions{}, fmt.Errorf("while encrypting sharedSecret: %w", err)
+		}
+
+		aead, err := chacha20poly1305.New(key[:])
+		if err != nil {
+			return SharedSecretEncryptions{}, fmt.Errorf("while encrypting sharedSecret: %w", err)
+		}
+
+		ciphertext := aead.Seal(nil, nonce[:], sharedSecret, nil)
+		encryptedSharedSecrets = append(encryptedSharedSecrets, encryptedSharedSecretAEAD{
+			Nonce:      nonce,
+			Ciphertext: ciphertext,
+		})
+	}
+
+	return SharedSecretEncryptions{
+		Scheme:             SchemeAEAD,
+		DiffieHellmanPoint: pkArray,
+		SharedSecretHash:   common.BytesToHash(crypto.Keccak256(sharedSecret)),
+		EncryptionsAEAD:    encryptedSharedSecrets,
+	}, nil
+}
+
+// DecryptSharedSecretAEAD recovers the shared secret encrypted for oracle
+// myIndex in enc, which must have been produced by EncryptSharedSecretAEAD.
+func DecryptSharedSecretAEAD(
+	enc SharedSecretEncryptions,
+	myIndex int,
+	mySecretKey [32]byte,
+) ([]byte, error) {
+	if enc.Scheme != SchemeAEAD {
+		return nil, fmt.Errorf("DecryptSharedSecretAEAD: unsupported scheme %d", enc.Scheme)
+	}
+	if myIndex < 0 || myIndex >= len(enc.EncryptionsAEAD) {
+		return nil, fmt.Errorf("myIndex %d out of range [0,%d)", myIndex, len(enc.EncryptionsAEAD))
+	}
+
+	dhPoint, err := curve25519.X25519(mySecretKey[:], enc.DiffieHellmanPoint[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting sharedSecret: %w", err)
+	}
+
+	myPublicKey := DerivePublicKey(mySecretKey)
+	key, nonce, err := deriveAEADKeyNonce(dhPoint, enc.DiffieHellmanPoint[:], myPublicKey[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting sharedSecret: %w", err)
+	}
+
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting sharedSecret: %w", err)
+	}
+
+	share := enc.EncryptionsAEAD[myIndex]
+	if nonce != share.Nonce {
+		return nil, fmt.Errorf("stored nonce does not match derived nonce")
+	}
+	sharedSecret, err := aead.Open(nil, nonce[:], share.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("while decrypting sharedSecret: %w", err)
+	}
+
+	if common.BytesToHash(crypto.Keccak256(sharedSecret)) != enc.SharedSecretHash {
+		return nil, fmt.Errorf("decrypted sharedSecret does not match SharedSecretHash")
+	}
+
+	return sharedSecret, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+func TestEncryptDecryptSharedSecretAEADRoundTrip(t *testing.T) {
+	const n = 4
+	secretKeys := make([][32]byte, n)
+	publicKeys := make([]SharedSecretEncryptionPublicKey, n)
+	for i := 0; i < n; i++ {
+		if _, err := io.ReadFull(rand.Reader, secretKeys[i][:]); err != nil {
+			t.Fatalf("generating secret key %d: %v", i, err)
+		}
+		publicKeys[i] = DerivePublicKey(secretKeys[i])
+	}
+
+	// A secret longer than the legacy SharedSecretSize, to exercise the lifted
+	// size restriction.
+	sharedSecret := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, sharedSecret); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc, err := EncryptSharedSecretAEAD(publicKeys, sharedSecret, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptSharedSecretAEAD: unexpected error: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		got, err := DecryptSharedSecretAEAD(enc, i, secretKeys[i])
+		if err != nil {
+			t.Fatalf("DecryptSharedSecretAEAD(%d): unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(got, sharedSecret) {
+			t.Fatalf("DecryptSharedSecretAEAD(%d): got %x, want %x", i, got, sharedSecret)
+		}
+	}
+}
+
+func TestDecryptSharedSecretAEADWrongScheme(t *testing.T) {
+	var sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, sk[:]); err != nil {
+		t.Fatalf("generating sk: %v", err)
+	}
+	var sharedSecret [SharedSecretSize]byte
+	if _, err := io.ReadFull(rand.Reader, sharedSecret[:]); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc := EncryptSharedSecret([]SharedSecretEncryptionPublicKey{DerivePublicKey(sk)}, &sharedSecret, rand.Reader)
+
+	if _, err := DecryptSharedSecretAEAD(enc, 0, sk); err == nil {
+		t.Fatalf("DecryptSharedSecretAEAD on a SchemeV1 encryption unexpectedly succeeded")
+	}
+}
+
+func TestDecryptSharedSecretAEADTamperedCiphertext(t *testing.T) {
+	var sk [32]byte
+	if _, err := io.ReadFull(rand.Reader, sk[:]); err != nil {
+		t.Fatalf("generating sk: %v", err)
+	}
+	sharedSecret := make([]byte, SharedSecretSize)
+	if _, err := io.ReadFull(rand.Reader, sharedSecret); err != nil {
+		t.Fatalf("generating shared secret: %v", err)
+	}
+
+	enc, err := EncryptSharedSecretAEAD([]SharedSecretEncryptionPublicKey{DerivePublicKey(sk)}, sharedSecret, rand.Reader)
+	if err != nil {
+		t.Fatalf("EncryptSharedSecretAEAD: unexpected error: %v", err)
+	}
+	enc.EncryptionsAEAD[0].Ciphertext[0] ^= 0xff
+
+	if _, err := DecryptSharedSecretAEAD(enc, 0, sk); err == nil {
+		t.Fatalf("DecryptSharedSecretAEAD of tampered ciphertext unexpectedly succeeded")
+	}
+}